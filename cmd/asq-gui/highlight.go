@@ -0,0 +1,101 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// Custom color names for chroma token categories, resolved through the
+// active theme so a config-driven theme (see ConfiguredTheme) can override
+// them the same way it overrides ColorNameMatchedText.
+const (
+	ColorNameKeyword fyne.ThemeColorName = "keyword"
+	ColorNameString  fyne.ThemeColorName = "string"
+	ColorNameComment fyne.ThemeColorName = "comment"
+	ColorNameNumber  fyne.ThemeColorName = "number"
+)
+
+// styledSpan is one lexer token's extent within a single line, given as
+// 0-based rune offsets.
+type styledSpan struct {
+	Start int
+	End   int // exclusive
+	Color color.Color
+}
+
+// lexFileLines lexes content by path's file extension and returns, for each
+// 0-based line, the token spans on that line. Paths with no matching lexer
+// use the fallback lexer. It returns nil if the lexer fails to tokenise.
+func lexFileLines(path, content string) [][]styledSpan {
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return nil
+	}
+
+	spans := make([][]styledSpan, strings.Count(content, "\n")+1)
+	line, col := 0, 0
+	for _, tok := range iterator.Tokens() {
+		fg := colorForTokenType(tok.Type)
+		for _, part := range strings.SplitAfter(tok.Value, "\n") {
+			if part == "" {
+				continue
+			}
+			text := strings.TrimSuffix(part, "\n")
+			if runeLen := utf8.RuneCountInString(text); runeLen > 0 {
+				spans[line] = append(spans[line], styledSpan{Start: col, End: col + runeLen, Color: fg})
+				col += runeLen
+			}
+			if strings.HasSuffix(part, "\n") {
+				line++
+				col = 0
+			}
+		}
+	}
+	return spans
+}
+
+// spansForLine looks up the token spans for 1-based lineNum, returning nil
+// (render the line as plain text) when lineNum has no counterpart, e.g. an
+// inserted/deleted line with no spans computed, or out-of-range.
+func spansForLine(lines [][]styledSpan, lineNum int) []styledSpan {
+	if lines == nil || lineNum <= 0 || lineNum > len(lines) {
+		return nil
+	}
+	return lines[lineNum-1]
+}
+
+// colorForTokenType maps a chroma token category to a themed foreground
+// color, falling back to the theme's normal foreground for anything that
+// isn't a keyword/string/comment/number.
+func colorForTokenType(t chroma.TokenType) color.Color {
+	switch {
+	case t.InCategory(chroma.Keyword):
+		return themeColor(ColorNameKeyword)
+	case t.InCategory(chroma.LiteralString):
+		return themeColor(ColorNameString)
+	case t.InCategory(chroma.Comment):
+		return themeColor(ColorNameComment)
+	case t.InCategory(chroma.LiteralNumber):
+		return themeColor(ColorNameNumber)
+	default:
+		return themeColor(theme.ColorNameForeground)
+	}
+}
+
+func themeColor(name fyne.ThemeColorName) color.Color {
+	settings := fyne.CurrentApp().Settings()
+	return settings.Theme().Color(name, settings.ThemeVariant())
+}
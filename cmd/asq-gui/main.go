@@ -3,63 +3,40 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"image/color"
 	"os"
-	"os/exec"
 	"strings"
-	"image/color"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-)
 
-type FileLocation struct {
-	Path      string
-	Line      int    // Starting line number
-	Column    int
-	LineCount int    // Number of lines in the matched text
-}
+	"github.com/StCredZero/asq-gui/internal/config"
+	"github.com/StCredZero/asq-gui/internal/state"
+)
 
-func parseFileLocation(line string) FileLocation {
+func parseFileLocation(line string) state.FileLocation {
 	parts := strings.Split(line, ":")
 	if len(parts) != 3 {
-		return FileLocation{Path: line, Line: 1, Column: 1}
+		return state.FileLocation{Path: line, Line: 1, Column: 1}
 	}
 
-	var loc FileLocation
+	var loc state.FileLocation
 	loc.Path = parts[0]
 	fmt.Sscanf(parts[1], "%d", &loc.Line)
 	fmt.Sscanf(parts[2], "%d", &loc.Column)
 	return loc
 }
 
-func getGitFileContent(path string, line int, column int) string {
-	cmd := exec.Command("git", "show", fmt.Sprintf("HEAD:%s", path))
-	output, err := cmd.Output()
-	if err != nil {
-		return fmt.Sprintf("Error reading git file: %v", err)
-	}
-	return string(output)
-}
-
-func getWorkingSetContent(path string) string {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Sprintf("Error reading file: %v", err)
-	}
-	return string(content)
-}
-
-func loadFileLocations(path string) []FileLocation {
+func loadFileLocations(path string) []state.FileLocation {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil
 	}
 	defer file.Close()
 
-	var locations []FileLocation
+	var locations []state.FileLocation
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		locations = append(locations, parseFileLocation(scanner.Text()))
@@ -67,54 +44,10 @@ func loadFileLocations(path string) []FileLocation {
 	return locations
 }
 
-// Custom color names for our theme
-const (
-	ColorNameMatchedText fyne.ThemeColorName = "matchedText"
-)
-
-// MyGreenBlackTheme implements a custom theme with green text on black background
-type MyGreenBlackTheme struct{}
-
-var _ fyne.Theme = (*MyGreenBlackTheme)(nil)
-
-func (m *MyGreenBlackTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
-	switch name {
-	case theme.ColorNameBackground:
-		return color.Black
-	case theme.ColorNameForeground:
-		return color.RGBA{0, 255, 0, 255} // bright green
-	case theme.ColorNameDisabled:
-		return color.RGBA{0, 128, 0, 255} // darker green for disabled state
-	case theme.ColorNameInputBackground:
-		return color.Black // ensure MultiLineEntry widgets have black background
-	case ColorNameMatchedText:
-		return color.RGBA{0, 0, 255, 255} // bright blue for matched text
-	case theme.ColorNameSeparator:
-		return color.Gray{Y: 128} // medium grey for split container dividers
-	// Return default colors for focus and selection to prevent blue background in list
-	case theme.ColorNameFocus, theme.ColorNameSelection, theme.ColorNameHover, theme.ColorNamePressed:
-		return theme.DefaultTheme().Color(name, variant)
-	default:
-		return theme.DefaultTheme().Color(name, variant)
-	}
-}
-
-func (m *MyGreenBlackTheme) Font(style fyne.TextStyle) fyne.Resource {
-	return theme.DefaultTheme().Font(style)
-}
-
-func (m *MyGreenBlackTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
-	return theme.DefaultTheme().Icon(name)
-}
-
-func (m *MyGreenBlackTheme) Size(name fyne.ThemeSizeName) float32 {
-	return theme.DefaultTheme().Size(name)
-}
-
-func loadAsqFromStdin() []FileLocation {
-	var locations []FileLocation
+func loadAsqFromStdin() []state.FileLocation {
+	var locations []state.FileLocation
 	scanner := bufio.NewScanner(os.Stdin)
-	var currentLoc *FileLocation
+	var currentLoc *state.FileLocation
 	var matchedLines []string
 
 	for scanner.Scan() {
@@ -135,22 +68,127 @@ func loadAsqFromStdin() []FileLocation {
 			matchedLines = append(matchedLines, line)
 		}
 	}
-	
+
 	// Handle the last match if any
 	if currentLoc != nil && len(matchedLines) > 0 {
 		currentLoc.LineCount = len(matchedLines)
 	}
-	
+
 	return locations
 }
 
+// Row styles for the diff panes. Equal lines use the theme's default
+// green-on-black; delete/insert lines get a tinted background so the
+// gutter reads as changed even before the matched-range overlay is applied.
+var (
+	diffEqualStyle = &widget.CustomTextGridStyle{
+		FGColor: color.RGBA{0, 255, 0, 255}, // bright green
+		BGColor: color.Black,
+	}
+	diffDeleteStyle = &widget.CustomTextGridStyle{
+		FGColor: color.RGBA{255, 120, 120, 255}, // soft red
+		BGColor: color.RGBA{60, 0, 0, 255},      // dark red gutter
+	}
+	diffInsertStyle = &widget.CustomTextGridStyle{
+		FGColor: color.RGBA{120, 255, 120, 255}, // soft green
+		BGColor: color.RGBA{0, 60, 0, 255},      // dark green gutter
+	}
+)
+
+// setDiffRow renders text into grid at rowIndex using base's colors.
+//
+// With spans == nil (no-highlight mode) the whole line gets base's flat
+// foreground/background, and a matched range overrides just the
+// foreground to blue, matching the pre-chroma behavior.
+//
+// With spans supplied (chroma highlighting enabled), each span keeps its
+// own lexer-derived foreground and base's background (the diff gutter
+// tint); a matched range instead overrides the background so it composes
+// with the per-token foreground colors rather than overwriting them.
+func setDiffRow(grid *widget.TextGrid, rowIndex int, text string, base *widget.CustomTextGridStyle, matched bool, spans []styledSpan) {
+	var row widget.TextGridRow
+	for _, r := range text {
+		row.Cells = append(row.Cells, widget.TextGridCell{Rune: r})
+	}
+	grid.SetRow(rowIndex, row)
+
+	if len(text) == 0 {
+		return
+	}
+
+	if spans == nil {
+		fg := base.FGColor
+		if matched {
+			fg = themeColor(ColorNameMatchedText)
+		}
+		grid.SetStyleRange(rowIndex, 0, rowIndex, len(text)-1, &widget.CustomTextGridStyle{FGColor: fg, BGColor: base.BGColor})
+		return
+	}
+
+	bg := base.BGColor
+	if matched {
+		bg = themeColor(ColorNameMatchedText)
+	}
+	for _, span := range spans {
+		grid.SetStyleRange(rowIndex, span.Start, rowIndex, span.End-1, &widget.CustomTextGridStyle{FGColor: span.Color, BGColor: bg})
+	}
+}
+
+// clearDiffRow blanks out a row, used to pad one side opposite an
+// insert/delete so the two grids stay visually aligned.
+func clearDiffRow(grid *widget.TextGrid, rowIndex int) {
+	grid.SetRow(rowIndex, widget.TextGridRow{})
+}
+
+// renderSideBySideDiff paints the two grids row-for-row from ops (the
+// Store's precomputed edit script for the selection), padding blank rows
+// opposite each insert/delete so line numbers stay aligned visually. When
+// highlight is true, each pane is additionally lexed with chroma and
+// rendered token-by-token instead of in a single flat color. It returns the
+// row index of the first line in the matched range on each side (or -1 if
+// not found), for scrolling.
+func renderSideBySideDiff(gitGrid, workingGrid *widget.TextGrid, ops []state.DiffOp, gitContent, workingContent string, loc state.FileLocation, highlight bool) (gitMatchRow, workingMatchRow int) {
+	var gitSpans, workingSpans [][]styledSpan
+	if highlight {
+		gitSpans = lexFileLines(loc.Path, gitContent)
+		workingSpans = lexFileLines(loc.Path, workingContent)
+	}
+
+	gitGrid.Resize(fyne.NewSize(gitGrid.Size().Width, float32(len(ops))))
+	workingGrid.Resize(fyne.NewSize(workingGrid.Size().Width, float32(len(ops))))
+
+	gitMatchRow, workingMatchRow = -1, -1
+	for rowIndex, op := range ops {
+		switch op.Kind {
+		case state.DiffEqual:
+			setDiffRow(gitGrid, rowIndex, op.Left, diffEqualStyle, state.InMatchedRange(op.LeftLine, loc), spansForLine(gitSpans, op.LeftLine))
+			setDiffRow(workingGrid, rowIndex, op.Right, diffEqualStyle, state.InMatchedRange(op.RightLine, loc), spansForLine(workingSpans, op.RightLine))
+		case state.DiffDelete:
+			setDiffRow(gitGrid, rowIndex, op.Left, diffDeleteStyle, state.InMatchedRange(op.LeftLine, loc), spansForLine(gitSpans, op.LeftLine))
+			clearDiffRow(workingGrid, rowIndex)
+		case state.DiffInsert:
+			clearDiffRow(gitGrid, rowIndex)
+			setDiffRow(workingGrid, rowIndex, op.Right, diffInsertStyle, state.InMatchedRange(op.RightLine, loc), spansForLine(workingSpans, op.RightLine))
+		}
+		if gitMatchRow == -1 && state.InMatchedRange(op.LeftLine, loc) {
+			gitMatchRow = rowIndex
+		}
+		if workingMatchRow == -1 && state.InMatchedRange(op.RightLine, loc) {
+			workingMatchRow = rowIndex
+		}
+	}
+	return gitMatchRow, workingMatchRow
+}
+
 func main() {
 	myApp := app.New()
 	window := myApp.NewWindow("ASQ GUI")
 
-	var locations []FileLocation
-	
-	// Create the list for the top pane
+	store := state.NewStore()
+
+	// Create the list for the top pane. Its data (`locations`) is only ever
+	// written from the Store subscriber below, never from OnSelected.
+	var locations []state.FileLocation
 	fileList := widget.NewList(
 		func() int { return len(locations) },
 		func() fyne.CanvasObject {
@@ -162,6 +200,7 @@ func main() {
 			label.SetText(fmt.Sprintf("%s:%d:%d", loc.Path, loc.Line, loc.Column))
 		},
 	)
+	fileList.OnSelected = func(id widget.ListItemID) { store.SelectLocation(id) }
 
 	// Create text grids for the bottom panes
 	gitCommitCode := widget.NewTextGrid()
@@ -172,7 +211,7 @@ func main() {
 	// Create scrollable containers for the code views
 	gitScroll := container.NewScroll(gitCommitCode)
 	workingScroll := container.NewScroll(workingSetCode)
-	
+
 	// Create split containers
 	bottomSplit := container.NewHSplit(
 		gitScroll,
@@ -180,8 +219,31 @@ func main() {
 	)
 	bottomSplit.SetOffset(0.5) // Equal split
 
-	mainSplit := container.NewVSplit(
+	// History panel: commits touching the currently selected location's
+	// file. Like `locations`, `commits` is only written from the Store
+	// subscriber.
+	var commits []state.CommitEntry
+	historyList := widget.NewList(
+		func() int { return len(commits) },
+		func() fyne.CanvasObject {
+			return widget.NewLabel("template")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			label := item.(*widget.Label)
+			commit := commits[id]
+			label.SetText(fmt.Sprintf("%s %s", commit.SHA[:7], commit.Subject))
+		},
+	)
+	historyList.OnSelected = func(id widget.ListItemID) { store.SelectCommit(id) }
+
+	topSplit := container.NewHSplit(
 		container.NewScroll(fileList),
+		container.NewScroll(historyList),
+	)
+	topSplit.SetOffset(0.5) // Equal split
+
+	mainSplit := container.NewVSplit(
+		topSplit,
 		bottomSplit,
 	)
 	mainSplit.SetOffset(0.3) // 30% top, 70% bottom
@@ -189,97 +251,119 @@ func main() {
 	window.SetContent(mainSplit)
 	window.Resize(fyne.NewSize(1024, 768))
 
-	// Apply custom theme for green text on black background
-	myApp.Settings().SetTheme(&MyGreenBlackTheme{})
+	// Strip --no-highlight and --light out of the args before looking for
+	// --display or a locations file path.
+	var noHighlight, lightFlag bool
+	var args []string
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--no-highlight":
+			noHighlight = true
+		case "--light":
+			lightFlag = true
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loading config:", err)
+		cfg = &config.Config{}
+	}
+	myApp.Settings().SetTheme(NewConfiguredTheme(cfg, lightFlag))
+
+	// render is the single place that turns a state.State into widget
+	// updates. It is only ever invoked from the Store subscriber, on the
+	// main goroutine via fyne.Do, so no OnSelected handler touches a widget
+	// directly.
+	renderedCommitSelection := -2 // sentinel so the first render always syncs the list
+	render := func(s state.State) {
+		locations = s.Locations
+		fileList.Refresh()
+
+		commits = s.Commits
+		historyList.Refresh()
+		if s.SelectedCommit != renderedCommitSelection {
+			renderedCommitSelection = s.SelectedCommit
+			if s.SelectedCommit >= 0 {
+				historyList.Select(s.SelectedCommit)
+			} else {
+				historyList.UnselectAll()
+			}
+		}
+
+		loc := s.SelectedLocation()
+		if loc.Path == "" {
+			return
+		}
+
+		gitMatchRow, workingMatchRow := renderSideBySideDiff(
+			gitCommitCode, workingSetCode, s.DiffOps, s.GitContent, s.WorkingContent, loc, !noHighlight,
+		)
+
+		// Scroll each pane to its own first matched row (assuming monospace font)
+		if gitMatchRow >= 0 {
+			lineHeight := gitCommitCode.MinSize().Height / float32(len(gitCommitCode.Rows))
+			gitScroll.Offset = fyne.NewPos(0, lineHeight*float32(gitMatchRow))
+			gitScroll.Refresh()
+		}
+		if workingMatchRow >= 0 {
+			lineHeight := workingSetCode.MinSize().Height / float32(len(workingSetCode.Rows))
+			workingScroll.Offset = fyne.NewPos(0, lineHeight*float32(workingMatchRow))
+			workingScroll.Refresh()
+		}
+	}
+	store.Subscribe(func(s state.State) {
+		fyne.Do(func() { render(s) })
+	})
 
 	// Load initial file locations from a file or stdin
-	if len(os.Args) > 1 {
-		if os.Args[1] == "--display" {
-			locations = loadAsqFromStdin()
-			fileList.Refresh()
+	if len(args) > 0 {
+		if args[0] == "--display" {
+			store.ReloadLocations(loadAsqFromStdin())
 		} else {
-			locations = loadFileLocations(os.Args[1])
-			fileList.Refresh()
+			store.ReloadLocations(loadFileLocations(args[0]))
 		}
 	}
 
-	// Handle list selection
-	fileList.OnSelected = func(id widget.ListItemID) {
-		if id < 0 || id >= len(locations) {
-			return
-		}
-		loc := locations[id]
-		
-		// Update git commit version
-		gitContent := getGitFileContent(loc.Path, loc.Line, loc.Column)
-		lines := strings.Split(gitContent, "\n")
-		gitCommitCode.Resize(fyne.NewSize(gitCommitCode.Size().Width, float32(len(lines))))
-		
-		// Calculate line height for scrolling (assuming monospace font)
-		lineHeight := gitCommitCode.MinSize().Height / float32(len(lines))
-		
-		for rowIndex, lineStr := range lines {
-			var row widget.TextGridRow
-			for _, r := range lineStr {
-				row.Cells = append(row.Cells, widget.TextGridCell{Rune: r})
+	// j/k or the down/up arrows walk the history panel without a mouse;
+	// s/r stage or revert the matched hunk.
+	window.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		s := store.State()
+		switch ev.Name {
+		case fyne.KeyJ, fyne.KeyDown:
+			if s.SelectedCommit < len(s.Commits)-1 {
+				store.SelectCommit(s.SelectedCommit + 1)
 			}
-			gitCommitCode.SetRow(rowIndex, row)
-			
-			// Set default green style for all text
-			greenStyle := &widget.CustomTextGridStyle{
-				FGColor: color.RGBA{0, 255, 0, 255}, // bright green
-				BGColor: color.Black,
+		case fyne.KeyK, fyne.KeyUp:
+			if s.SelectedCommit <= 0 {
+				store.SelectCommit(0)
+			} else {
+				store.SelectCommit(s.SelectedCommit - 1)
 			}
-			gitCommitCode.SetStyleRange(rowIndex, 0, rowIndex, len(lineStr)-1, greenStyle)
-			
-			// Apply blue color to matched line range (convert from 1-based to 0-based index)
-			if rowIndex >= loc.Line-1 && rowIndex < loc.Line-1+loc.LineCount {
-				blueStyle := &widget.CustomTextGridStyle{
-					FGColor: color.RGBA{0, 0, 255, 255}, // bright blue
-					BGColor: color.Black,
-				}
-				gitCommitCode.SetStyleRange(rowIndex, 0, rowIndex, len(lineStr)-1, blueStyle)
+		case fyne.KeyS:
+			loc := s.SelectedLocation()
+			if loc.Path == "" {
+				return
 			}
-		}
-		
-		// Scroll to matched line range
-		matchedLineY := lineHeight * float32(loc.Line-1)
-		gitScroll.Offset = fyne.NewPos(0, matchedLineY)
-		gitScroll.Refresh()
-		
-		// Update working set version
-		workingContent := getWorkingSetContent(loc.Path)
-		lines = strings.Split(workingContent, "\n")
-		workingSetCode.Resize(fyne.NewSize(workingSetCode.Size().Width, float32(len(lines))))
-		
-		for rowIndex, lineStr := range lines {
-			var row widget.TextGridRow
-			for _, r := range lineStr {
-				row.Cells = append(row.Cells, widget.TextGridCell{Rune: r})
+			if err := stageMatchedRange(loc); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
 			}
-			workingSetCode.SetRow(rowIndex, row)
-			
-			// Set default green style for all text
-			greenStyle := &widget.CustomTextGridStyle{
-				FGColor: color.RGBA{0, 255, 0, 255}, // bright green
-				BGColor: color.Black,
+			store.Refresh()
+		case fyne.KeyR:
+			loc := s.SelectedLocation()
+			if loc.Path == "" {
+				return
 			}
-			workingSetCode.SetStyleRange(rowIndex, 0, rowIndex, len(lineStr)-1, greenStyle)
-			
-			// Apply blue color to matched line range (convert from 1-based to 0-based index)
-			if rowIndex >= loc.Line-1 && rowIndex < loc.Line-1+loc.LineCount {
-				blueStyle := &widget.CustomTextGridStyle{
-					FGColor: color.RGBA{0, 0, 255, 255}, // bright blue
-					BGColor: color.Black,
-				}
-				workingSetCode.SetStyleRange(rowIndex, 0, rowIndex, len(lineStr)-1, blueStyle)
+			if err := revertMatchedRange(loc); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
 			}
+			store.Refresh()
 		}
-		
-		// Scroll working set to matched line range
-		workingScroll.Offset = fyne.NewPos(0, matchedLineY)
-		workingScroll.Refresh()
-	}
+	})
 
 	window.ShowAndRun()
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/StCredZero/asq-gui/internal/state"
+)
+
+// patchContextLines is how many lines of unchanged context surround the
+// matched range in the synthesized hunk, matching typical unified-diff
+// defaults.
+const patchContextLines = 3
+
+// buildMatchedRangePatch synthesizes a single-hunk unified diff, in the
+// style `git apply` expects, covering just the edit script ops that touch
+// loc's matched range (plus patchContextLines of surrounding context on
+// either side). It reuses state.ComputeLineDiff rather than re-deriving the
+// change, so the hunk agrees with the side-by-side diff pane while that pane
+// diffs HEAD against the working copy; when a historical commit is selected
+// the pane shows a different diff than the one patched here. ok is false if
+// the matched range has no change to patch.
+func buildMatchedRangePatch(path, gitContent, workingContent string, loc state.FileLocation) (patch string, ok bool) {
+	gitLines := state.SplitLines(gitContent)
+	workingLines := state.SplitLines(workingContent)
+	ops := state.ComputeLineDiff(gitLines, workingLines)
+
+	startIdx, endIdx := -1, -1
+	for i, op := range ops {
+		if op.Kind == state.DiffEqual {
+			continue
+		}
+		if state.InMatchedRange(op.LeftLine, loc) || state.InMatchedRange(op.RightLine, loc) {
+			if startIdx == -1 {
+				startIdx = i
+			}
+			endIdx = i
+		}
+	}
+	if startIdx == -1 {
+		return "", false
+	}
+
+	for c := 0; c < patchContextLines && startIdx > 0 && ops[startIdx-1].Kind == state.DiffEqual; c++ {
+		startIdx--
+	}
+	for c := 0; c < patchContextLines && endIdx < len(ops)-1 && ops[endIdx+1].Kind == state.DiffEqual; c++ {
+		endIdx++
+	}
+	hunk := ops[startIdx : endIdx+1]
+
+	var oldStart, newStart, oldCount, newCount int
+	var body strings.Builder
+	for _, op := range hunk {
+		switch op.Kind {
+		case state.DiffEqual:
+			if oldStart == 0 {
+				oldStart = op.LeftLine
+			}
+			if newStart == 0 {
+				newStart = op.RightLine
+			}
+			body.WriteString(" " + op.Left + "\n")
+			oldCount++
+			newCount++
+		case state.DiffDelete:
+			if oldStart == 0 {
+				oldStart = op.LeftLine
+			}
+			body.WriteString("-" + op.Left + "\n")
+			oldCount++
+		case state.DiffInsert:
+			if newStart == 0 {
+				newStart = op.RightLine
+			}
+			body.WriteString("+" + op.Right + "\n")
+			newCount++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n", path)
+	fmt.Fprintf(&out, "+++ b/%s\n", path)
+	fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	out.WriteString(body.String())
+	return out.String(), true
+}
+
+// stageMatchedRange stages just loc's matched line range by synthesizing a
+// patch and handing it to `git apply --cached`.
+func stageMatchedRange(loc state.FileLocation) error {
+	return applyMatchedRangePatch(loc, "--cached")
+}
+
+// revertMatchedRange discards the working-copy edits within loc's matched
+// line range, splicing HEAD's lines back in, by applying the same
+// synthesized patch in reverse against the working tree.
+func revertMatchedRange(loc state.FileLocation) error {
+	return applyMatchedRangePatch(loc, "-R")
+}
+
+func applyMatchedRangePatch(loc state.FileLocation, applyFlag string) error {
+	gitContent := state.GetGitFileContent(loc.Path)
+	workingContent := state.GetWorkingSetContent(loc.Path)
+
+	patch, ok := buildMatchedRangePatch(loc.Path, gitContent, workingContent, loc)
+	if !ok {
+		return fmt.Errorf("no changes in matched range of %s to apply", loc.Path)
+	}
+
+	cmd := exec.Command("git", "apply", applyFlag, "-")
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git apply %s: %v: %s", applyFlag, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/StCredZero/asq-gui/internal/state"
+)
+
+func TestBuildMatchedRangePatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		gitContent      string
+		workingContent  string
+		loc             state.FileLocation
+		wantOK          bool
+		wantHunkPresent string // substring that must appear in the hunk body when wantOK
+	}{
+		{
+			name:            "matched range edited",
+			gitContent:      "a\nb\nc\nd\n",
+			workingContent:  "a\nBEE\nc\nd\n",
+			loc:             state.FileLocation{Line: 2, LineCount: 1},
+			wantOK:          true,
+			wantHunkPresent: "+BEE",
+		},
+		{
+			name:            "matched range edited at end of file",
+			gitContent:      "a\nb\nc\nd\n",
+			workingContent:  "a\nb\nc\nZZZ\n",
+			loc:             state.FileLocation{Line: 4, LineCount: 1},
+			wantOK:          true,
+			wantHunkPresent: "+ZZZ",
+		},
+		{
+			name:           "matched range unchanged, edit elsewhere far away",
+			gitContent:     "a\nb\nc\nd\ne\nf\ng\nh\ni\n",
+			workingContent: "a\nb\nc\nd\ne\nf\ng\nh\nZZZ\n",
+			loc:            state.FileLocation{Line: 2, LineCount: 1},
+			wantOK:         false,
+		},
+		{
+			name:           "no changes at all",
+			gitContent:     "a\nb\nc\n",
+			workingContent: "a\nb\nc\n",
+			loc:            state.FileLocation{Line: 1, LineCount: 1},
+			wantOK:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, ok := buildMatchedRangePatch("f.txt", tt.gitContent, tt.workingContent, tt.loc)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v (patch: %q)", ok, tt.wantOK, patch)
+			}
+			if !ok {
+				return
+			}
+			if !strings.Contains(patch, tt.wantHunkPresent) {
+				t.Errorf("patch %q does not contain %q", patch, tt.wantHunkPresent)
+			}
+			hasChange := false
+			for _, line := range strings.Split(patch, "\n") {
+				if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+					if !strings.HasPrefix(line, "+++") && !strings.HasPrefix(line, "---") {
+						hasChange = true
+					}
+				}
+			}
+			if !hasChange {
+				t.Errorf("patch %q has no +/- lines, git apply would reject it as corrupt", patch)
+			}
+			checkPatchApplies(t, patch, tt.gitContent)
+		})
+	}
+}
+
+// checkPatchApplies verifies patch actually applies cleanly with `git apply
+// --check --cached` against a real repo whose committed f.txt is gitContent,
+// catching hunk-header/line-count mistakes that substring assertions on the
+// patch text miss.
+func checkPatchApplies(t *testing.T, patch, gitContent string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s %v: %v: %s", name, args, err, out)
+		}
+	}
+	run("git", "init", "-q")
+	run("git", "config", "user.email", "test@example.com")
+	run("git", "config", "user.name", "test")
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(gitContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("git", "add", "f.txt")
+	run("git", "commit", "-q", "-m", "initial")
+
+	cmd := exec.Command("git", "apply", "--check", "--cached", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git apply --check --cached: %v: %s\npatch:\n%s", err, out, patch)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/StCredZero/asq-gui/internal/config"
+)
+
+// Custom color names for our theme.
+const (
+	ColorNameMatchedText fyne.ThemeColorName = "matchedText"
+)
+
+// ConfiguredTheme implements fyne.Theme from a preset palette (dark
+// green-on-black, or light if requested) with per-color overrides from the
+// user's config.yaml. It replaces the old hardcoded MyGreenBlackTheme.
+type ConfiguredTheme struct {
+	colors map[fyne.ThemeColorName]color.Color
+}
+
+var _ fyne.Theme = (*ConfiguredTheme)(nil)
+
+// NewConfiguredTheme builds the theme to use for this run: light starts
+// from the light preset instead of the dark default (true if cfg.Theme.
+// LightTheme is set, or the --light flag was passed), then applies any hex
+// color overrides from cfg on top.
+func NewConfiguredTheme(cfg *config.Config, light bool) *ConfiguredTheme {
+	light = light || cfg.Theme.LightTheme
+
+	colors := darkPalette()
+	if light {
+		colors = lightPalette()
+	}
+	applyOverrides(colors, cfg.Theme)
+
+	return &ConfiguredTheme{colors: colors}
+}
+
+// darkPalette is the original green-on-black look.
+func darkPalette() map[fyne.ThemeColorName]color.Color {
+	return map[fyne.ThemeColorName]color.Color{
+		theme.ColorNameBackground:      color.Black,
+		theme.ColorNameForeground:      color.RGBA{0, 255, 0, 255}, // bright green
+		theme.ColorNameDisabled:        color.RGBA{0, 128, 0, 255}, // darker green for disabled state
+		theme.ColorNameInputBackground: color.Black,
+		theme.ColorNameSeparator:       color.Gray{Y: 128}, // medium grey for split container dividers
+		ColorNameMatchedText:           color.RGBA{0, 0, 255, 255},
+		ColorNameKeyword:               color.RGBA{0, 255, 255, 255},
+		ColorNameString:                color.RGBA{255, 255, 0, 255},
+		ColorNameComment:               color.RGBA{0, 160, 0, 255},
+		ColorNameNumber:                color.RGBA{255, 165, 0, 255},
+	}
+}
+
+// lightPalette is dark text on white, with a darker blue for the matched
+// range so it keeps enough contrast against a white background.
+func lightPalette() map[fyne.ThemeColorName]color.Color {
+	return map[fyne.ThemeColorName]color.Color{
+		theme.ColorNameBackground:      color.White,
+		theme.ColorNameForeground:      color.Black,
+		theme.ColorNameDisabled:        color.Gray{Y: 160},
+		theme.ColorNameInputBackground: color.White,
+		theme.ColorNameSeparator:       color.Gray{Y: 190},
+		ColorNameMatchedText:           color.RGBA{0, 0, 150, 255}, // darker blue for contrast on white
+		ColorNameKeyword:               color.RGBA{0, 0, 200, 255},
+		ColorNameString:                color.RGBA{140, 80, 0, 255},
+		ColorNameComment:               color.RGBA{110, 110, 110, 255},
+		ColorNameNumber:                color.RGBA{170, 80, 0, 255},
+	}
+}
+
+// applyOverrides replaces any palette entry with a matching non-empty hex
+// color from cfg, leaving the preset default in place otherwise.
+func applyOverrides(colors map[fyne.ThemeColorName]color.Color, cfg config.ThemeConfig) {
+	overrides := map[fyne.ThemeColorName]string{
+		theme.ColorNameBackground: cfg.Background,
+		theme.ColorNameForeground: cfg.Foreground,
+		ColorNameMatchedText:      cfg.MatchedText,
+		ColorNameKeyword:          cfg.Keyword,
+		ColorNameString:           cfg.String,
+		ColorNameComment:          cfg.Comment,
+		ColorNameNumber:           cfg.Number,
+	}
+	for name, hex := range overrides {
+		if hex == "" {
+			continue
+		}
+		c, err := parseHexColor(hex)
+		if err != nil {
+			continue
+		}
+		colors[name] = c
+	}
+}
+
+// parseHexColor parses a "#rrggbb" or "#rrggbbaa" string into a color.Color.
+func parseHexColor(hex string) (color.Color, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 && len(hex) != 8 {
+		return nil, fmt.Errorf("invalid hex color %q", hex)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex color %q: %w", hex, err)
+	}
+	return color.RGBA{
+		R: uint8(v >> 24),
+		G: uint8(v >> 16),
+		B: uint8(v >> 8),
+		A: uint8(v),
+	}, nil
+}
+
+func (t *ConfiguredTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	// Leave focus/selection/hover/pressed alone to avoid a blue-tinted list
+	// selection clashing with the rest of the palette.
+	switch name {
+	case theme.ColorNameFocus, theme.ColorNameSelection, theme.ColorNameHover, theme.ColorNamePressed:
+		return theme.DefaultTheme().Color(name, variant)
+	}
+	if c, ok := t.colors[name]; ok {
+		return c
+	}
+	return theme.DefaultTheme().Color(name, variant)
+}
+
+func (t *ConfiguredTheme) Font(style fyne.TextStyle) fyne.Resource {
+	return theme.DefaultTheme().Font(style)
+}
+
+func (t *ConfiguredTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	return theme.DefaultTheme().Icon(name)
+}
+
+func (t *ConfiguredTheme) Size(name fyne.ThemeSizeName) float32 {
+	return theme.DefaultTheme().Size(name)
+}
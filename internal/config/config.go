@@ -0,0 +1,62 @@
+// Package config loads asq-gui's user configuration file.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeConfig is the `theme:` section of config.yaml. All color fields are
+// hex strings ("#rrggbb"); a blank field means "use the preset default".
+type ThemeConfig struct {
+	LightTheme  bool   `yaml:"lightTheme"`
+	Background  string `yaml:"background,omitempty"`
+	Foreground  string `yaml:"foreground,omitempty"`
+	MatchedText string `yaml:"matchedText,omitempty"`
+	Keyword     string `yaml:"keyword,omitempty"`
+	String      string `yaml:"string,omitempty"`
+	Comment     string `yaml:"comment,omitempty"`
+	Number      string `yaml:"number,omitempty"`
+}
+
+// Config is the root of config.yaml.
+type Config struct {
+	Theme ThemeConfig `yaml:"theme"`
+}
+
+// Load reads and parses ~/.config/asq-gui/config.yaml. A missing file is
+// not an error: Load returns a zero-value Config so callers fall back to
+// preset defaults.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Path returns the location of the user's config file,
+// ~/.config/asq-gui/config.yaml.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "asq-gui", "config.yaml"), nil
+}
@@ -0,0 +1,69 @@
+package state
+
+import "strings"
+
+// SplitLines splits content into the lines it actually contains.
+// strings.Split(content, "\n") alone turns a trailing newline (the normal
+// end-of-file case) into a phantom trailing "" element that isn't a real
+// line in the file; SplitLines strips it so line counts and line numbers
+// derived from the result match the file on disk.
+func SplitLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// ComputeLineDiff returns the LCS-based edit script turning a into b. It
+// walks the longest-common-subsequence table backwards from (len(a), len(b))
+// to (0, 0), preferring an Insert over a Delete when both sides of the table
+// tie, which keeps inserted lines grouped together in the output.
+func ComputeLineDiff(a, b []string) []DiffOp {
+	table := lcsTable(a, b)
+
+	var ops []DiffOp
+	i, j := len(a), len(b)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && a[i-1] == b[j-1]:
+			ops = append(ops, DiffOp{Kind: DiffEqual, Left: a[i-1], Right: b[j-1], LeftLine: i, RightLine: j})
+			i--
+			j--
+		case j > 0 && (i == 0 || table[i][j-1] >= table[i-1][j]):
+			ops = append(ops, DiffOp{Kind: DiffInsert, Right: b[j-1], RightLine: j})
+			j--
+		default:
+			ops = append(ops, DiffOp{Kind: DiffDelete, Left: a[i-1], LeftLine: i})
+			i--
+		}
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
+
+// lcsTable builds the standard dynamic-programming longest-common-subsequence
+// table for a and b, sized (len(a)+1) x (len(b)+1).
+func lcsTable(a, b []string) [][]int {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				table[i][j] = table[i-1][j-1] + 1
+			case table[i-1][j] >= table[i][j-1]:
+				table[i][j] = table[i-1][j]
+			default:
+				table[i][j] = table[i][j-1]
+			}
+		}
+	}
+	return table
+}
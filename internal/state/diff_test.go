@@ -0,0 +1,99 @@
+package state
+
+import "testing"
+
+func TestComputeLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want []DiffOp
+	}{
+		{
+			name: "both empty",
+			a:    nil,
+			b:    nil,
+			want: nil,
+		},
+		{
+			name: "a empty, all inserts",
+			a:    nil,
+			b:    []string{"x", "y"},
+			want: []DiffOp{
+				{Kind: DiffInsert, Right: "x", RightLine: 1},
+				{Kind: DiffInsert, Right: "y", RightLine: 2},
+			},
+		},
+		{
+			name: "b empty, all deletes",
+			a:    []string{"x", "y"},
+			b:    nil,
+			want: []DiffOp{
+				{Kind: DiffDelete, Left: "x", LeftLine: 1},
+				{Kind: DiffDelete, Left: "y", LeftLine: 2},
+			},
+		},
+		{
+			name: "identical, all equal",
+			a:    []string{"x", "y"},
+			b:    []string{"x", "y"},
+			want: []DiffOp{
+				{Kind: DiffEqual, Left: "x", Right: "x", LeftLine: 1, RightLine: 1},
+				{Kind: DiffEqual, Left: "y", Right: "y", LeftLine: 2, RightLine: 2},
+			},
+		},
+		{
+			name: "interleaved equal/insert/delete",
+			a:    []string{"a", "b", "c", "d"},
+			b:    []string{"a", "BEE", "c", "d", "e"},
+			want: []DiffOp{
+				{Kind: DiffEqual, Left: "a", Right: "a", LeftLine: 1, RightLine: 1},
+				{Kind: DiffDelete, Left: "b", LeftLine: 2},
+				{Kind: DiffInsert, Right: "BEE", RightLine: 2},
+				{Kind: DiffEqual, Left: "c", Right: "c", LeftLine: 3, RightLine: 3},
+				{Kind: DiffEqual, Left: "d", Right: "d", LeftLine: 4, RightLine: 4},
+				{Kind: DiffInsert, Right: "e", RightLine: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeLineDiff(tt.a, tt.b)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ComputeLineDiff() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("op %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "trailing newline", content: "a\nb\nc\n", want: []string{"a", "b", "c"}},
+		{name: "no trailing newline", content: "a\nb\nc", want: []string{"a", "b", "c"}},
+		{name: "empty", content: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitLines(tt.content)
+			if len(got) != len(tt.want) {
+				t.Fatalf("SplitLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("SplitLines(%q)[%d] = %q, want %q", tt.content, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
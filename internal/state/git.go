@@ -0,0 +1,56 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GetGitFileContent returns path's content as of HEAD.
+func GetGitFileContent(path string) string {
+	return GetCommitFileContent("HEAD", path)
+}
+
+// GetCommitFileContent returns path's content as of sha.
+func GetCommitFileContent(sha, path string) string {
+	cmd := exec.Command("git", "show", fmt.Sprintf("%s:%s", sha, path))
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Sprintf("Error reading git file: %v", err)
+	}
+	return string(output)
+}
+
+// GetWorkingSetContent returns path's content in the working copy.
+func GetWorkingSetContent(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("Error reading file: %v", err)
+	}
+	return string(content)
+}
+
+// GetFileHistory returns the commits that touched path, following renames,
+// newest first. It returns nil if the path has no history (e.g. outside a
+// git repo, or not yet committed).
+func GetFileHistory(path string) []CommitEntry {
+	cmd := exec.Command("git", "log", "--follow", "--pretty=format:%H%x09%s", "--", path)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var commits []CommitEntry
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		commits = append(commits, CommitEntry{SHA: parts[0], Subject: parts[1]})
+	}
+	return commits
+}
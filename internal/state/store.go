@@ -0,0 +1,140 @@
+package state
+
+import (
+	"sync"
+)
+
+// State is the entire state of the GUI: the loaded locations, which one
+// (and which historical commit, if any) is selected, and the diff that
+// selection produced. Every widget is rendered as a pure function of this
+// struct; nothing else holds GUI-relevant state.
+type State struct {
+	Locations      []FileLocation
+	SelectedIndex  int
+	Commits        []CommitEntry
+	SelectedCommit int
+	GitContent     string
+	WorkingContent string
+	DiffOps        []DiffOp
+}
+
+// SelectedLocation returns the currently selected location, or the zero
+// value if none is selected.
+func (s State) SelectedLocation() FileLocation {
+	if s.SelectedIndex < 0 || s.SelectedIndex >= len(s.Locations) {
+		return FileLocation{}
+	}
+	return s.Locations[s.SelectedIndex]
+}
+
+// Store holds the single State and notifies subscribers whenever an action
+// replaces it. It has no knowledge of Fyne; callers are responsible for
+// marshaling notifications onto the UI goroutine (e.g. with fyne.Do).
+type Store struct {
+	mu          sync.Mutex
+	state       State
+	subscribers []func(State)
+}
+
+// NewStore returns an empty Store with nothing selected.
+func NewStore() *Store {
+	return &Store{state: State{SelectedIndex: -1, SelectedCommit: -1}}
+}
+
+// Subscribe registers fn to be called with every new State after an
+// action. It is not called with the current state at subscribe time;
+// callers that need an initial render should read State() themselves.
+func (s *Store) Subscribe(fn func(State)) {
+	s.mu.Lock()
+	s.subscribers = append(s.subscribers, fn)
+	s.mu.Unlock()
+}
+
+// State returns the current state.
+func (s *Store) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+func (s *Store) commit(next State) {
+	s.mu.Lock()
+	s.state = next
+	subs := make([]func(State), len(s.subscribers))
+	copy(subs, s.subscribers)
+	s.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(next)
+	}
+}
+
+// ReloadLocations replaces the location list (e.g. after loading a new asq
+// run or file) and clears any prior selection.
+func (s *Store) ReloadLocations(locations []FileLocation) {
+	s.commit(State{
+		Locations:      locations,
+		SelectedIndex:  -1,
+		SelectedCommit: -1,
+	})
+}
+
+// SelectLocation selects locations[index] at HEAD, loading its scoped
+// history and diffing HEAD against the working copy. Out-of-range indexes
+// are ignored.
+func (s *Store) SelectLocation(index int) {
+	cur := s.State()
+	if index < 0 || index >= len(cur.Locations) {
+		return
+	}
+	loc := cur.Locations[index]
+
+	gitContent := GetGitFileContent(loc.Path)
+	workingContent := GetWorkingSetContent(loc.Path)
+
+	s.commit(State{
+		Locations:      cur.Locations,
+		SelectedIndex:  index,
+		Commits:        GetFileHistory(loc.Path),
+		SelectedCommit: -1,
+		GitContent:     gitContent,
+		WorkingContent: workingContent,
+		DiffOps:        ComputeLineDiff(SplitLines(gitContent), SplitLines(workingContent)),
+	})
+}
+
+// SelectCommit re-diffs the currently selected location's left pane against
+// the chosen historical commit instead of HEAD. It is a no-op if no
+// location, or an out-of-range commit, is selected.
+func (s *Store) SelectCommit(index int) {
+	cur := s.State()
+	if cur.SelectedIndex < 0 || index < 0 || index >= len(cur.Commits) {
+		return
+	}
+	loc := cur.Locations[cur.SelectedIndex]
+
+	gitContent := GetCommitFileContent(cur.Commits[index].SHA, loc.Path)
+
+	next := cur
+	next.SelectedCommit = index
+	next.GitContent = gitContent
+	next.DiffOps = ComputeLineDiff(SplitLines(gitContent), SplitLines(cur.WorkingContent))
+	s.commit(next)
+}
+
+// Refresh re-runs SelectLocation (or SelectCommit, if one is active) against
+// the current selection, picking up changes made outside the store, e.g.
+// staging or reverting the matched hunk.
+func (s *Store) Refresh() {
+	cur := s.State()
+	if cur.SelectedIndex < 0 {
+		return
+	}
+	if cur.SelectedCommit >= 0 {
+		commit := cur.SelectedCommit
+		s.SelectLocation(cur.SelectedIndex)
+		s.SelectCommit(commit)
+		return
+	}
+	s.SelectLocation(cur.SelectedIndex)
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// withTempRepo creates a git repo in a temp dir with f.txt committed as
+// gitContent, writes workingContent over it uncommitted (if different from
+// gitContent), chdirs the test into it (restoring cwd on cleanup), and
+// returns the repo dir.
+func withTempRepo(t *testing.T, gitContent, workingContent string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s %v: %v: %s", name, args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(gitContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("git", "init", "-q")
+	run("git", "config", "user.email", "test@example.com")
+	run("git", "config", "user.name", "test")
+	run("git", "add", "f.txt")
+	run("git", "commit", "-q", "-m", "initial")
+
+	if workingContent != gitContent {
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte(workingContent), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	return dir
+}
+
+func TestStoreSelectLocation(t *testing.T) {
+	withTempRepo(t, "a\nb\nc\n", "a\nBEE\nc\n")
+
+	s := NewStore()
+	s.ReloadLocations([]FileLocation{{Path: "f.txt", Line: 2, LineCount: 1}})
+	s.SelectLocation(0)
+
+	got := s.State()
+	if got.SelectedIndex != 0 {
+		t.Errorf("SelectedIndex = %d, want 0", got.SelectedIndex)
+	}
+	if got.GitContent != "a\nb\nc\n" {
+		t.Errorf("GitContent = %q, want %q", got.GitContent, "a\nb\nc\n")
+	}
+	if got.WorkingContent != "a\nBEE\nc\n" {
+		t.Errorf("WorkingContent = %q, want %q", got.WorkingContent, "a\nBEE\nc\n")
+	}
+	if len(got.DiffOps) == 0 {
+		t.Fatal("DiffOps is empty, want a diff between HEAD and working copy")
+	}
+
+	// Out-of-range index is a no-op.
+	s.SelectLocation(5)
+	if s.State().SelectedIndex != 0 {
+		t.Errorf("SelectLocation(5) changed SelectedIndex to %d, want unchanged 0", s.State().SelectedIndex)
+	}
+}
+
+func TestStoreSelectCommit(t *testing.T) {
+	dir := withTempRepo(t, "a\nb\nc\n", "a\nb\nc\n")
+
+	// Add a second commit so there's scoped history to select against.
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("a\nb\nc\nd\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%s %v: %v: %s", name, args, err, out)
+		}
+	}
+	run("git", "add", "f.txt")
+	run("git", "commit", "-q", "-m", "second")
+	// Back to the working copy the test cares about diffing against HEAD~1.
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("a\nb\nc\nZZZ\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStore()
+	s.ReloadLocations([]FileLocation{{Path: "f.txt", Line: 4, LineCount: 1}})
+	s.SelectLocation(0)
+
+	if len(s.State().Commits) < 2 {
+		t.Fatalf("Commits = %+v, want at least 2 entries", s.State().Commits)
+	}
+
+	s.SelectCommit(1) // the first ("initial") commit
+	got := s.State()
+	if got.SelectedCommit != 1 {
+		t.Errorf("SelectedCommit = %d, want 1", got.SelectedCommit)
+	}
+	if got.GitContent != "a\nb\nc\n" {
+		t.Errorf("GitContent = %q, want %q", got.GitContent, "a\nb\nc\n")
+	}
+	if got.WorkingContent != "a\nb\nc\nZZZ\n" {
+		t.Errorf("WorkingContent unexpectedly changed by SelectCommit: %q", got.WorkingContent)
+	}
+
+	// Out-of-range commit index is a no-op.
+	s.SelectCommit(99)
+	if s.State().SelectedCommit != 1 {
+		t.Errorf("SelectCommit(99) changed SelectedCommit to %d, want unchanged 1", s.State().SelectedCommit)
+	}
+}
+
+func TestStoreRefresh(t *testing.T) {
+	dir := withTempRepo(t, "a\nb\nc\n", "a\nBEE\nc\n")
+
+	s := NewStore()
+	s.ReloadLocations([]FileLocation{{Path: "f.txt", Line: 2, LineCount: 1}})
+	s.SelectLocation(0)
+
+	// Refresh with no selection is a no-op.
+	empty := NewStore()
+	empty.Refresh()
+	if empty.State().SelectedIndex != -1 {
+		t.Errorf("Refresh on empty store set SelectedIndex to %d, want -1", empty.State().SelectedIndex)
+	}
+
+	// Mutate the working copy outside the store, then Refresh should pick
+	// it up, same as after staging/reverting the matched hunk.
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("a\nCEE\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.Refresh()
+	if got := s.State().WorkingContent; got != "a\nCEE\nc\n" {
+		t.Errorf("WorkingContent after Refresh = %q, want %q", got, "a\nCEE\nc\n")
+	}
+
+	// Refresh while a historical commit is selected re-applies SelectCommit.
+	s.SelectCommit(0)
+	s.Refresh()
+	if s.State().SelectedCommit != 0 {
+		t.Errorf("SelectedCommit after Refresh = %d, want unchanged 0", s.State().SelectedCommit)
+	}
+}
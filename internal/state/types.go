@@ -0,0 +1,44 @@
+package state
+
+// FileLocation is a single asq match: a path plus the line/column range the
+// match covers.
+type FileLocation struct {
+	Path      string
+	Line      int // Starting line number
+	Column    int
+	LineCount int // Number of lines in the matched text
+}
+
+// CommitEntry is one row of a file's scoped git history: a commit that
+// touched the file, in `git log` order (newest first).
+type CommitEntry struct {
+	SHA     string
+	Subject string
+}
+
+// DiffOpKind identifies whether a DiffOp is shared between both sides of a
+// diff, or present on only one side.
+type DiffOpKind int
+
+const (
+	DiffEqual DiffOpKind = iota
+	DiffInsert
+	DiffDelete
+)
+
+// DiffOp is one step of an edit script turning the left line slice into the
+// right line slice. LeftLine/RightLine are the 1-based line numbers the
+// content came from, or 0 when the op has no counterpart on that side.
+type DiffOp struct {
+	Kind      DiffOpKind
+	Left      string
+	Right     string
+	LeftLine  int
+	RightLine int
+}
+
+// InMatchedRange reports whether the 1-based line number falls within the
+// matched range of loc. A lineNum of 0 means "no counterpart on this side".
+func InMatchedRange(lineNum int, loc FileLocation) bool {
+	return lineNum > 0 && lineNum >= loc.Line && lineNum < loc.Line+loc.LineCount
+}